@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/dynamic-resource-allocation/deviceattribute"
+	"k8s.io/utils/ptr"
+)
+
+// HealthState represents the last observed health of a GPU.
+type HealthState string
+
+const (
+	// Healthy indicates the GPU has no outstanding health concerns.
+	Healthy HealthState = "Healthy"
+	// Degraded indicates the GPU should be scheduled with caution.
+	Degraded HealthState = "Degraded"
+	// Unhealthy indicates the GPU should not be scheduled.
+	Unhealthy HealthState = "Unhealthy"
+)
+
+// deviceAttributePrefix is the common prefix used for all GPU-specific
+// device attributes published by this driver.
+const deviceAttributePrefix = "gpu.nvidia.com/"
+
+var pciBusIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{4}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-9a-fA-F]$`)
+
+// GpuInfo captures the set of properties gathered for a single physical GPU
+// that are needed to publish it as a ResourceSlice device.
+//
+// The health, xidLast, eccUncorrectable, remappedRowsPending, and
+// healthSince fields are mutated by a HealthMonitor's background goroutine
+// while GetDevice may be called concurrently from the ResourceSlice
+// publication path; healthMu guards all of them. No other field is mutated
+// after the GpuInfo set for the node has been enumerated and topology/PCIe
+// segment computation has run.
+type GpuInfo struct {
+	UUID                  string
+	minor                 int
+	memoryBytes           uint64
+	productName           string
+	brand                 string
+	architecture          string
+	cudaComputeCapability string
+	driverVersion         string
+	cudaDriverVersion     string
+	pcieBusID             string
+	pcieRootAttr          *deviceattribute.DeviceAttribute
+	numaNode              int
+	health                HealthState
+
+	// p2pLinks maps a peer GPU's minor number to the strongest NVML-reported
+	// P2P connection type between this GPU and that peer. Keyed by minor
+	// rather than UUID because the UUID can't be embedded in a
+	// QualifiedName's identifier (no dots/hyphens, max DeviceMaxIDLength).
+	p2pLinks map[int]P2PLinkType
+	// nvlinkPeerCount is the number of distinct peer GPUs this GPU shares
+	// at least one active NVLink connection with.
+	nvlinkPeerCount int
+	// topologyGroup is a stable identifier for the connected component of
+	// GPUs this device belongs to under the configured link-quality
+	// threshold (see computeGpuTopology).
+	topologyGroup string
+
+	// pcieSegment is the stable "segN" ID assigned by computePcieSegments to
+	// the group of GPUs sharing this GPU's pcieRootAttr value.
+	pcieSegment string
+	// pcieSegmentPeers is the number of other GPUs in the same pcieSegment.
+	pcieSegmentPeers int
+
+	// xidLast is the most recent NVML XID error code observed for this GPU,
+	// or 0 if none has been observed yet.
+	xidLast int
+	// eccUncorrectable is the cumulative uncorrectable ECC error count.
+	eccUncorrectable int64
+	// remappedRowsPending is the number of memory rows pending remap that
+	// require a GPU reset to take effect.
+	remappedRowsPending int64
+	// healthSince is when health last transitioned to its current
+	// Degraded/Unhealthy value, set once by HealthMonitor at the moment of
+	// transition. It backs the DeviceTaint's TimeAdded so republishing an
+	// already-tainted device doesn't keep resetting it to "now".
+	healthSince time.Time
+	// healthMu guards health, xidLast, eccUncorrectable,
+	// remappedRowsPending, and healthSince.
+	healthMu sync.Mutex
+}
+
+// GetDevice converts this GpuInfo into the resourceapi.Device that gets
+// published in the node's ResourceSlice.
+func (g *GpuInfo) GetDevice() *resourceapi.Device {
+	device := &resourceapi.Device{
+		Name: fmt.Sprintf("gpu-%d", g.minor),
+		Attributes: map[resourceapi.QualifiedName]resourceapi.DeviceAttribute{
+			deviceAttributePrefix + "uuid":                  {StringValue: ptr.To(g.UUID)},
+			deviceAttributePrefix + "productName":           {StringValue: ptr.To(g.productName)},
+			deviceAttributePrefix + "brand":                 {StringValue: ptr.To(g.brand)},
+			deviceAttributePrefix + "architecture":          {StringValue: ptr.To(g.architecture)},
+			deviceAttributePrefix + "cudaComputeCapability": {StringValue: ptr.To(g.cudaComputeCapability)},
+			deviceAttributePrefix + "driverVersion":         {StringValue: ptr.To(g.driverVersion)},
+			deviceAttributePrefix + "cudaDriverVersion":     {StringValue: ptr.To(g.cudaDriverVersion)},
+		},
+	}
+
+	if g.numaNode >= 0 {
+		device.Attributes[deviceAttributePrefix+"numaNode"] = resourceapi.DeviceAttribute{
+			IntValue: ptr.To(int64(g.numaNode)),
+		}
+	}
+
+	if g.pcieRootAttr != nil {
+		device.Attributes[g.pcieRootAttr.Name] = g.pcieRootAttr.Value
+	}
+
+	g.addTopologyAttributes(device.Attributes)
+	g.addPcieSegmentAttributes(device.Attributes)
+	g.addHealthAttributes(device)
+
+	return device
+}
+
+// PartDevAttributes returns the subset of device attributes shared between a
+// full GPU and any MIG partitions carved out of it.
+func (g *GpuInfo) PartDevAttributes() map[resourceapi.QualifiedName]resourceapi.DeviceAttribute {
+	attrs := map[resourceapi.QualifiedName]resourceapi.DeviceAttribute{
+		deviceAttributePrefix + "productName":           {StringValue: ptr.To(g.productName)},
+		deviceAttributePrefix + "brand":                 {StringValue: ptr.To(g.brand)},
+		deviceAttributePrefix + "architecture":          {StringValue: ptr.To(g.architecture)},
+		deviceAttributePrefix + "cudaComputeCapability": {StringValue: ptr.To(g.cudaComputeCapability)},
+		deviceAttributePrefix + "driverVersion":         {StringValue: ptr.To(g.driverVersion)},
+		deviceAttributePrefix + "cudaDriverVersion":     {StringValue: ptr.To(g.cudaDriverVersion)},
+	}
+
+	if g.numaNode >= 0 {
+		attrs[deviceAttributePrefix+"numaNode"] = resourceapi.DeviceAttribute{
+			IntValue: ptr.To(int64(g.numaNode)),
+		}
+	}
+
+	return attrs
+}
+
+// isPCIBusID reports whether s is a well-formed PCI bus ID of the form
+// "dddd:dd:dd.d" (domain:bus:device.function, all hex).
+func isPCIBusID(s string) bool {
+	return pciBusIDRegexp.MatchString(s)
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	resourceapi "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// XIDEvent is a single NVML XID error reported for a GPU.
+type XIDEvent struct {
+	UUID string
+	XID  int
+}
+
+// xidEventSource abstracts nvmlEventSetWait so the health monitor can be
+// unit tested without a real NVML library.
+type xidEventSource interface {
+	// WaitForEvent blocks until an XID event occurs or ctx is cancelled.
+	WaitForEvent(ctx context.Context) (XIDEvent, error)
+}
+
+// HealthPolicy configures which XID codes affect health and how quickly a
+// GPU is allowed to recover.
+type HealthPolicy struct {
+	// DegradedXIDs transition a GPU to Degraded (NoSchedule).
+	DegradedXIDs map[int]bool
+	// UnhealthyXIDs transition a GPU to Unhealthy (NoExecute).
+	UnhealthyXIDs map[int]bool
+	// IgnoredXIDs are never acted on, even if also listed above.
+	IgnoredXIDs map[int]bool
+	// CleanPollsToRecover is the number of consecutive healthy polls
+	// required before a Degraded/Unhealthy GPU is un-tainted.
+	CleanPollsToRecover int
+}
+
+// DefaultHealthPolicy returns the driver's built-in XID classification,
+// matching the codes NVIDIA documents as requiring a workload drain.
+func DefaultHealthPolicy() HealthPolicy {
+	return HealthPolicy{
+		DegradedXIDs: map[int]bool{
+			48: true, // Double Bit ECC Error
+			63: true, // Row Remapping event
+		},
+		UnhealthyXIDs: map[int]bool{
+			64: true, // Row Remap Failure
+			79: true, // GPU has fallen off the bus
+			94: true, // Contained ECC error
+			95: true, // Uncontained ECC error
+		},
+		IgnoredXIDs:         map[int]bool{},
+		CleanPollsToRecover: 3,
+	}
+}
+
+// HealthMonitor watches a set of GPUs for XID events, transitioning each
+// GpuInfo's health state and calling onHealthChange when it changes. It does
+// not read ECC or remapped-row counters: eccUncorrectable and
+// remappedRowsPending are published as they are set on the GpuInfo, and do
+// not affect health.
+//
+// handleEvent and PollRecovery are safe to call concurrently (e.g. from the
+// XID event goroutine and a separate ECC/remapped-row polling ticker): mu
+// guards pollCounts, and the GpuInfo fields they mutate are themselves
+// guarded by GpuInfo.healthMu.
+type HealthMonitor struct {
+	policy HealthPolicy
+	source xidEventSource
+
+	mu         sync.Mutex
+	pollCounts map[string]int // consecutive clean polls per GPU UUID, for hysteresis
+
+	// onHealthChange is invoked with the GPU whose health just changed, so
+	// the caller can republish the owning ResourceSlice.
+	onHealthChange func(*GpuInfo)
+}
+
+// NewHealthMonitor constructs a HealthMonitor using policy to classify XID
+// events read from source. onHealthChange is called whenever a GPU's health
+// state transitions.
+func NewHealthMonitor(policy HealthPolicy, source xidEventSource, onHealthChange func(*GpuInfo)) *HealthMonitor {
+	return &HealthMonitor{
+		policy:         policy,
+		source:         source,
+		pollCounts:     map[string]int{},
+		onHealthChange: onHealthChange,
+	}
+}
+
+// Run consumes XID events from the event source until ctx is cancelled,
+// updating the matching entry in gpus by UUID. It is intended to be run in
+// its own goroutine.
+func (m *HealthMonitor) Run(ctx context.Context, gpus map[string]*GpuInfo) error {
+	for {
+		event, err := m.source.WaitForEvent(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		gpu, ok := gpus[event.UUID]
+		if !ok || m.policy.IgnoredXIDs[event.XID] {
+			continue
+		}
+
+		m.handleEvent(gpu, event.XID)
+	}
+}
+
+// handleEvent applies a single XID event's effect to gpu's health state and
+// notifies onHealthChange if it changed.
+func (m *HealthMonitor) handleEvent(gpu *GpuInfo, xid int) {
+	m.mu.Lock()
+	gpu.healthMu.Lock()
+
+	gpu.xidLast = xid
+
+	prev := gpu.health
+	switch {
+	case m.policy.UnhealthyXIDs[xid]:
+		gpu.health = Unhealthy
+		m.pollCounts[gpu.UUID] = 0
+	case m.policy.DegradedXIDs[xid]:
+		if gpu.health != Unhealthy {
+			gpu.health = Degraded
+		}
+		m.pollCounts[gpu.UUID] = 0
+	}
+
+	changed := gpu.health != prev
+	if changed {
+		gpu.healthSince = time.Now()
+	}
+
+	gpu.healthMu.Unlock()
+	m.mu.Unlock()
+
+	// onHealthChange typically republishes the GPU's ResourceSlice device
+	// (calling GetDevice, which itself takes gpu.healthMu), so it must run
+	// with both locks released to avoid deadlocking against ourselves.
+	if changed && m.onHealthChange != nil {
+		m.onHealthChange(gpu)
+	}
+}
+
+// PollRecovery records one clean polling interval (no new XID events) for
+// gpu. Once CleanPollsToRecover consecutive clean polls have been observed,
+// a Degraded or Unhealthy GPU is returned to Healthy.
+func (m *HealthMonitor) PollRecovery(gpu *GpuInfo) {
+	m.mu.Lock()
+	gpu.healthMu.Lock()
+
+	recovered := false
+	if gpu.health != Healthy {
+		m.pollCounts[gpu.UUID]++
+		if m.pollCounts[gpu.UUID] >= m.policy.CleanPollsToRecover {
+			gpu.health = Healthy
+			gpu.healthSince = time.Time{}
+			delete(m.pollCounts, gpu.UUID)
+			recovered = true
+		}
+	}
+
+	gpu.healthMu.Unlock()
+	m.mu.Unlock()
+
+	// See handleEvent: onHealthChange must run without either lock held.
+	if recovered && m.onHealthChange != nil {
+		m.onHealthChange(gpu)
+	}
+}
+
+// addHealthAttributes adds the XID/ECC/remapped-row attributes and, for
+// Degraded or Unhealthy GPUs, a resource.k8s.io DeviceTaint onto device.
+// TimeAdded is stamped with when the GPU actually transitioned into its
+// current health state (see HealthMonitor.handleEvent/PollRecovery), not
+// with the time of this call, so republishing an already-tainted device
+// doesn't keep resetting its taint's grace-period clock.
+func (g *GpuInfo) addHealthAttributes(device *resourceapi.Device) {
+	g.healthMu.Lock()
+	defer g.healthMu.Unlock()
+
+	if g.xidLast != 0 {
+		device.Attributes[deviceAttributePrefix+"xidLast"] = resourceapi.DeviceAttribute{
+			IntValue: ptr.To(int64(g.xidLast)),
+		}
+	}
+	device.Attributes[deviceAttributePrefix+"eccUncorrectable"] = resourceapi.DeviceAttribute{
+		IntValue: ptr.To(g.eccUncorrectable),
+	}
+	device.Attributes[deviceAttributePrefix+"remappedRowsPending"] = resourceapi.DeviceAttribute{
+		IntValue: ptr.To(g.remappedRowsPending),
+	}
+
+	switch g.health {
+	case Degraded:
+		device.Taints = append(device.Taints, resourceapi.DeviceTaint{
+			Key:       deviceAttributePrefix + "health",
+			Value:     string(Degraded),
+			Effect:    resourceapi.DeviceTaintEffectNoSchedule,
+			TimeAdded: ptr.To(metav1.NewTime(g.healthSince)),
+		})
+	case Unhealthy:
+		device.Taints = append(device.Taints, resourceapi.DeviceTaint{
+			Key:       deviceAttributePrefix + "health",
+			Value:     string(Unhealthy),
+			Effect:    resourceapi.DeviceTaintEffectNoExecute,
+			TimeAdded: ptr.To(metav1.NewTime(g.healthSince)),
+		})
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+)
+
+func TestHealthMonitor_HandleEvent_Transitions(t *testing.T) {
+	testCases := []struct {
+		description   string
+		xid           int
+		startHealth   HealthState
+		expectHealth  HealthState
+		expectChanged bool
+	}{
+		{
+			description:   "degraded XID on healthy GPU",
+			xid:           48,
+			startHealth:   Healthy,
+			expectHealth:  Degraded,
+			expectChanged: true,
+		},
+		{
+			description:   "unhealthy XID on healthy GPU",
+			xid:           79,
+			startHealth:   Healthy,
+			expectHealth:  Unhealthy,
+			expectChanged: true,
+		},
+		{
+			description:   "degraded XID does not downgrade an unhealthy GPU",
+			xid:           48,
+			startHealth:   Unhealthy,
+			expectHealth:  Unhealthy,
+			expectChanged: false,
+		},
+		{
+			description:   "unrelated XID has no effect",
+			xid:           1,
+			startHealth:   Healthy,
+			expectHealth:  Healthy,
+			expectChanged: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			gpu := newTestGpuInfo("GPU-0", 0)
+			gpu.health = tc.startHealth
+
+			var changed *GpuInfo
+			monitor := NewHealthMonitor(DefaultHealthPolicy(), nil, func(g *GpuInfo) { changed = g })
+			monitor.handleEvent(gpu, tc.xid)
+
+			require.Equal(t, tc.expectHealth, gpu.health)
+			require.Equal(t, tc.xid, gpu.xidLast)
+			if tc.expectChanged {
+				require.Same(t, gpu, changed)
+			} else {
+				require.Nil(t, changed)
+			}
+		})
+	}
+}
+
+func TestHealthMonitor_PollRecovery_Hysteresis(t *testing.T) {
+	gpu := newTestGpuInfo("GPU-0", 0)
+	gpu.health = Degraded
+
+	policy := DefaultHealthPolicy()
+	policy.CleanPollsToRecover = 3
+
+	var changes int
+	monitor := NewHealthMonitor(policy, nil, func(*GpuInfo) { changes++ })
+
+	monitor.PollRecovery(gpu)
+	monitor.PollRecovery(gpu)
+	require.Equal(t, Degraded, gpu.health, "should not recover before CleanPollsToRecover")
+	require.Equal(t, 0, changes)
+
+	monitor.PollRecovery(gpu)
+	require.Equal(t, Healthy, gpu.health)
+	require.Equal(t, 1, changes)
+}
+
+func TestGpuInfo_GetDevice_HealthTaints(t *testing.T) {
+	testCases := []struct {
+		health         HealthState
+		expectTaint    bool
+		expectedEffect resourceapi.DeviceTaintEffect
+	}{
+		{health: Healthy, expectTaint: false},
+		{health: Degraded, expectTaint: true, expectedEffect: resourceapi.DeviceTaintEffectNoSchedule},
+		{health: Unhealthy, expectTaint: true, expectedEffect: resourceapi.DeviceTaintEffectNoExecute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.health), func(t *testing.T) {
+			gpu := newTestGpuInfo("GPU-0", 0)
+			gpu.health = tc.health
+			gpu.eccUncorrectable = 2
+			gpu.remappedRowsPending = 1
+
+			device := gpu.GetDevice()
+
+			eccAttr := device.Attributes[deviceAttributePrefix+"eccUncorrectable"]
+			require.Equal(t, int64(2), *eccAttr.IntValue)
+			rowsAttr := device.Attributes[deviceAttributePrefix+"remappedRowsPending"]
+			require.Equal(t, int64(1), *rowsAttr.IntValue)
+
+			if tc.expectTaint {
+				require.Len(t, device.Taints, 1)
+				require.Equal(t, tc.expectedEffect, device.Taints[0].Effect)
+			} else {
+				require.Empty(t, device.Taints)
+			}
+		})
+	}
+}
+
+func TestGpuInfo_GetDevice_HealthTaint_TimeAddedStableAcrossRepublish(t *testing.T) {
+	gpu := newTestGpuInfo("GPU-0", 0)
+	monitor := NewHealthMonitor(DefaultHealthPolicy(), nil, nil)
+	monitor.handleEvent(gpu, 48) // Degraded XID
+
+	first := gpu.GetDevice()
+	require.Len(t, first.Taints, 1)
+
+	time.Sleep(2 * time.Millisecond)
+	second := gpu.GetDevice()
+	require.Len(t, second.Taints, 1)
+
+	require.Equal(t, first.Taints[0].TimeAdded, second.Taints[0].TimeAdded,
+		"TimeAdded must record the health transition, not be refreshed on every GetDevice call")
+}
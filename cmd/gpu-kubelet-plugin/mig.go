@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/utils/ptr"
+)
+
+// MigInfo describes a single MIG (Multi-Instance GPU) partition carved out
+// of a parent GpuInfo.
+type MigInfo struct {
+	// InstanceID is a stable identifier for this partition, derived from its
+	// GPU instance ID and compute instance ID (e.g. "gi0-ci0"), stable
+	// across driver restarts for the same physical slice.
+	InstanceID string
+	// Profile is the MIG profile name, e.g. "1g.5gb".
+	Profile string
+	// ParentUUID is the UUID of the parent GpuInfo this partition was
+	// carved from.
+	ParentUUID string
+
+	parent *GpuInfo
+}
+
+// GetDevice converts this MigInfo into the resourceapi.Device published in
+// the node's ResourceSlice, inheriting the shared attributes from its
+// parent GPU via PartDevAttributes.
+func (m *MigInfo) GetDevice() *resourceapi.Device {
+	attrs := m.parent.PartDevAttributes()
+	attrs[deviceAttributePrefix+"migInstanceID"] = resourceapi.DeviceAttribute{StringValue: ptr.To(m.InstanceID)}
+	attrs[deviceAttributePrefix+"migProfile"] = resourceapi.DeviceAttribute{StringValue: ptr.To(m.Profile)}
+	attrs[deviceAttributePrefix+"parentGpuUUID"] = resourceapi.DeviceAttribute{StringValue: ptr.To(m.ParentUUID)}
+
+	return &resourceapi.Device{
+		Name:       fmt.Sprintf("gpu-%d-mig-%s", m.parent.minor, m.InstanceID),
+		Attributes: attrs,
+	}
+}
+
+// MigInstanceKey uniquely identifies a MIG partition across the whole node.
+// InstanceID alone is only unique within its parent GPU (e.g. "gi0-ci0"
+// exists once per GPU), so any constraint keyed on instance identity must
+// include ParentUUID too.
+type MigInstanceKey struct {
+	ParentUUID string
+	InstanceID string
+}
+
+// MigSelector is the subset of a claim's selector expressions that target
+// MIG-specific fields. It mirrors the fix where instance IDs must actually
+// be checked against user constraints rather than any instance of the
+// parent GPU satisfying the request.
+type MigSelector struct {
+	// ExcludeInstances rejects any candidate whose (ParentUUID, InstanceID)
+	// is listed here, even if its parent otherwise matches.
+	ExcludeInstances map[MigInstanceKey]bool
+}
+
+// SelectSameParentMigInstances picks count MIG partitions of the given
+// profile that all share a single parent GPU, honoring selector. Candidate
+// parents are tried in ascending UUID order so the result is deterministic
+// for a fixed input set. It returns an error if no single parent has enough
+// matching, non-excluded instances.
+func SelectSameParentMigInstances(instances []*MigInfo, profile string, count int, selector MigSelector) ([]*MigInfo, error) {
+	byParent := map[string][]*MigInfo{}
+	for _, inst := range instances {
+		if inst.Profile != profile {
+			continue
+		}
+		if selector.ExcludeInstances[MigInstanceKey{ParentUUID: inst.ParentUUID, InstanceID: inst.InstanceID}] {
+			continue
+		}
+		byParent[inst.ParentUUID] = append(byParent[inst.ParentUUID], inst)
+	}
+
+	parents := make([]string, 0, len(byParent))
+	for p := range byParent {
+		parents = append(parents, p)
+	}
+	sort.Strings(parents)
+
+	for _, p := range parents {
+		if len(byParent[p]) >= count {
+			return byParent[p][:count], nil
+		}
+	}
+
+	return nil, fmt.Errorf("mig: no parent GPU has %d available %q instances matching the claim's constraints", count, profile)
+}
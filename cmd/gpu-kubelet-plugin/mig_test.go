@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testMigInstances() (gpu0, gpu1 *GpuInfo, instances []*MigInfo) {
+	gpu0 = newTestGpuInfo("GPU-0", 0)
+	gpu1 = newTestGpuInfo("GPU-1", 1)
+
+	instances = []*MigInfo{
+		{InstanceID: "gi0-ci0", Profile: "1g.5gb", ParentUUID: "GPU-0", parent: gpu0},
+		{InstanceID: "gi1-ci0", Profile: "1g.5gb", ParentUUID: "GPU-0", parent: gpu0},
+		{InstanceID: "gi2-ci0", Profile: "1g.5gb", ParentUUID: "GPU-0", parent: gpu0},
+		{InstanceID: "gi0-ci0", Profile: "1g.5gb", ParentUUID: "GPU-1", parent: gpu1},
+	}
+	return
+}
+
+func TestSelectSameParentMigInstances_RejectsMixedParents(t *testing.T) {
+	_, _, instances := testMigInstances()
+
+	selected, err := SelectSameParentMigInstances(instances, "1g.5gb", 2, MigSelector{})
+	require.NoError(t, err)
+	require.Len(t, selected, 2)
+	for _, inst := range selected {
+		require.Equal(t, "GPU-0", inst.ParentUUID, "all selected instances must share the same parent")
+	}
+}
+
+func TestSelectSameParentMigInstances_NotEnoughOnAnyParent(t *testing.T) {
+	_, _, instances := testMigInstances()
+
+	_, err := SelectSameParentMigInstances(instances, "1g.5gb", 4, MigSelector{})
+	require.Error(t, err)
+}
+
+func TestSelectSameParentMigInstances_ExcludesSpecificInstanceID(t *testing.T) {
+	_, _, instances := testMigInstances()
+
+	// Excluding gi0-ci0 on GPU-0 still leaves 2 usable instances there.
+	selected, err := SelectSameParentMigInstances(instances, "1g.5gb", 2, MigSelector{
+		ExcludeInstances: map[MigInstanceKey]bool{{ParentUUID: "GPU-0", InstanceID: "gi0-ci0"}: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, selected, 2)
+	for _, inst := range selected {
+		require.NotEqual(t, "gi0-ci0", inst.InstanceID)
+	}
+}
+
+func TestSelectSameParentMigInstances_ExclusionIsScopedToParent(t *testing.T) {
+	_, _, instances := testMigInstances()
+
+	// Excluding GPU-1's gi0-ci0 must not also exclude GPU-0's identically
+	// numbered, healthy gi0-ci0 instance.
+	selected, err := SelectSameParentMigInstances(instances, "1g.5gb", 2, MigSelector{
+		ExcludeInstances: map[MigInstanceKey]bool{{ParentUUID: "GPU-1", InstanceID: "gi0-ci0"}: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, selected, 2)
+	for _, inst := range selected {
+		require.Equal(t, "GPU-0", inst.ParentUUID)
+	}
+	require.Contains(t, []string{selected[0].InstanceID, selected[1].InstanceID}, "gi0-ci0")
+}
+
+func TestSelectSameParentMigInstances_ExcludingAllFailsRequest(t *testing.T) {
+	_, _, instances := testMigInstances()
+
+	// Excluding every GPU-0 instance ID leaves only 1 instance on GPU-1,
+	// which is not enough for a request of 2 on a single parent.
+	_, err := SelectSameParentMigInstances(instances, "1g.5gb", 2, MigSelector{
+		ExcludeInstances: map[MigInstanceKey]bool{
+			{ParentUUID: "GPU-0", InstanceID: "gi0-ci0"}: true,
+			{ParentUUID: "GPU-0", InstanceID: "gi1-ci0"}: true,
+			{ParentUUID: "GPU-0", InstanceID: "gi2-ci0"}: true,
+		},
+	})
+	require.Error(t, err)
+}
+
+func TestMigInfo_GetDevice(t *testing.T) {
+	gpu0, _, instances := testMigInstances()
+	inst := instances[0]
+
+	device := inst.GetDevice()
+	require.Equal(t, "gpu-0-mig-gi0-ci0", device.Name)
+
+	require.Equal(t, "gi0-ci0", *device.Attributes[deviceAttributePrefix+"migInstanceID"].StringValue)
+	require.Equal(t, "1g.5gb", *device.Attributes[deviceAttributePrefix+"migProfile"].StringValue)
+	require.Equal(t, "GPU-0", *device.Attributes[deviceAttributePrefix+"parentGpuUUID"].StringValue)
+	require.Equal(t, gpu0.productName, *device.Attributes[deviceAttributePrefix+"productName"].StringValue)
+}
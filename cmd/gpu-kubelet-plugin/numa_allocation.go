@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/anson627/k8s-dra-driver-gpu/pkg/numa"
+)
+
+// NumaConfig controls whether the kubelet plugin factors NUMA topology into
+// allocation. Setting ExcludeTopology makes SelectNumaAllocation return no
+// NUMA placement, mirroring the SR-IOV device plugin's excludeTopology knob.
+// It does not affect publication: GetDevice and PartDevAttributes still
+// publish the numaNode attribute.
+type NumaConfig struct {
+	ExcludeTopology bool
+}
+
+// NumaAllocation is the result of scoring a claim's candidate GPU set
+// against the host's NUMA topology, ready to be translated into CDI
+// containerEdits cpuset hints.
+type NumaAllocation struct {
+	// NodeIDs are the distinct NUMA node(s) the chosen GPUs live on.
+	NodeIDs []int
+	Score   numa.CandidateScore
+}
+
+// CPUSetHint renders the chosen NUMA nodes' CPUs as a cpuset.cpus-style
+// list, e.g. "0-3,8-11", suitable for a CDI containerEdits hook.
+func (a *NumaAllocation) CPUSetHint(topo *numa.Topology) string {
+	var cpus []int
+	for _, id := range a.NodeIDs {
+		node, ok := topo.Nodes[id]
+		if !ok {
+			continue
+		}
+		cpus = append(cpus, node.CPUs...)
+	}
+	return formatCPUList(cpus)
+}
+
+// MemSetHint renders the chosen NUMA nodes as a cpuset.mems-style list,
+// e.g. "0,1".
+func (a *NumaAllocation) MemSetHint() string {
+	ids := append([]int(nil), a.NodeIDs...)
+	sort.Ints(ids)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("%d", id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// SelectNumaAllocation picks the best-scoring NUMA placement for a
+// multi-device claim. candidates is a set of equally-valid GPU-set options
+// already produced by the allocator (e.g. different combinations of GPUs
+// satisfying the claim's device class); gpuNodes maps each GpuInfo UUID to
+// its NUMA node. preferredCPUs comes from the claim's
+// "gpu.nvidia.com/preferredCPUs" parameter, if any.
+//
+// If cfg.ExcludeTopology is set, SelectNumaAllocation returns nil so the
+// Topology Manager is left out of the decision entirely.
+func SelectNumaAllocation(cfg NumaConfig, topo *numa.Topology, candidates [][]*GpuInfo, gpuNodes map[string]int, preferredCPUs []int) (*NumaAllocation, []*GpuInfo, error) {
+	if cfg.ExcludeTopology {
+		return nil, nil, nil
+	}
+	if topo == nil {
+		return nil, nil, fmt.Errorf("numa: topology unavailable")
+	}
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("numa: no candidate GPU sets to score")
+	}
+
+	var best *NumaAllocation
+	var bestSet []*GpuInfo
+
+	for _, set := range candidates {
+		nodeIDs := make([]int, 0, len(set))
+		for _, gpu := range set {
+			nodeID, ok := gpuNodes[gpu.UUID]
+			if !ok {
+				return nil, nil, fmt.Errorf("numa: no NUMA node recorded for GPU %s", gpu.UUID)
+			}
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+
+		score := topo.ScoreNodes(nodeIDs, preferredCPUs)
+		alloc := &NumaAllocation{NodeIDs: dedupInts(nodeIDs), Score: score}
+
+		if best == nil || betterAllocation(score, best.Score) {
+			best = alloc
+			bestSet = set
+		}
+	}
+
+	return best, bestSet, nil
+}
+
+// betterAllocation reports whether candidate scores higher than current:
+// same-NUMA-node placements win outright, then lower summed distance, then
+// higher preferred-CPU overlap.
+func betterAllocation(candidate, current numa.CandidateScore) bool {
+	if candidate.SameNode != current.SameNode {
+		return candidate.SameNode
+	}
+	if candidate.Distance != current.Distance {
+		return candidate.Distance < current.Distance
+	}
+	return candidate.CPUOverlap > current.CPUOverlap
+}
+
+func dedupInts(in []int) []int {
+	seen := map[int]bool{}
+	var out []int
+	for _, v := range in {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+func formatCPUList(cpus []int) string {
+	if len(cpus) == 0 {
+		return ""
+	}
+	sorted := append([]int(nil), cpus...)
+	sort.Ints(sorted)
+
+	var ranges []string
+	start := sorted[0]
+	prev := sorted[0]
+	for _, c := range sorted[1:] {
+		if c == prev+1 {
+			prev = c
+			continue
+		}
+		ranges = append(ranges, formatRange(start, prev))
+		start, prev = c, c
+	}
+	ranges = append(ranges, formatRange(start, prev))
+
+	return strings.Join(ranges, ",")
+}
+
+func formatRange(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
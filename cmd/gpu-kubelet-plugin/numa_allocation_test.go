@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anson627/k8s-dra-driver-gpu/pkg/numa"
+)
+
+func testTopology() *numa.Topology {
+	return &numa.Topology{
+		Nodes: map[int]*numa.Node{
+			0: {ID: 0, CPUs: []int{0, 1, 2, 3}},
+			1: {ID: 1, CPUs: []int{4, 5, 6, 7}},
+		},
+		Distances: map[int]map[int]int{
+			0: {0: 10, 1: 21},
+			1: {0: 21, 1: 10},
+		},
+	}
+}
+
+func TestSelectNumaAllocation_PrefersSameNode(t *testing.T) {
+	gpu0 := newTestGpuInfo("GPU-0", 0)
+	gpu1 := newTestGpuInfo("GPU-1", 1)
+	gpu2 := newTestGpuInfo("GPU-2", 2)
+
+	gpuNodes := map[string]int{
+		"GPU-0": 0,
+		"GPU-1": 0,
+		"GPU-2": 1,
+	}
+
+	candidates := [][]*GpuInfo{
+		{gpu0, gpu2}, // split across node 0 and node 1
+		{gpu0, gpu1}, // both on node 0
+	}
+
+	alloc, set, err := SelectNumaAllocation(NumaConfig{}, testTopology(), candidates, gpuNodes, nil)
+	require.NoError(t, err)
+	require.True(t, alloc.Score.SameNode)
+	require.Equal(t, []int{0}, alloc.NodeIDs)
+	require.ElementsMatch(t, []*GpuInfo{gpu0, gpu1}, set)
+}
+
+func TestSelectNumaAllocation_MissingGpuNodeFails(t *testing.T) {
+	gpu0 := newTestGpuInfo("GPU-0", 0)
+	gpu1 := newTestGpuInfo("GPU-1", 1)
+
+	// gpuNodes has no entry for GPU-1: the candidate must be rejected, not
+	// silently scored as if GPU-1 were on node 0.
+	gpuNodes := map[string]int{"GPU-0": 0}
+	candidates := [][]*GpuInfo{{gpu0, gpu1}}
+
+	alloc, set, err := SelectNumaAllocation(NumaConfig{}, testTopology(), candidates, gpuNodes, nil)
+	require.Error(t, err)
+	require.Nil(t, alloc)
+	require.Nil(t, set)
+}
+
+func TestSelectNumaAllocation_ExcludeTopology(t *testing.T) {
+	gpu0 := newTestGpuInfo("GPU-0", 0)
+	candidates := [][]*GpuInfo{{gpu0}}
+
+	alloc, set, err := SelectNumaAllocation(NumaConfig{ExcludeTopology: true}, testTopology(), candidates, map[string]int{"GPU-0": 0}, nil)
+	require.NoError(t, err)
+	require.Nil(t, alloc)
+	require.Nil(t, set)
+}
+
+func TestNumaAllocation_CPUSetHint(t *testing.T) {
+	alloc := &NumaAllocation{NodeIDs: []int{0}}
+	require.Equal(t, "0-3", alloc.CPUSetHint(testTopology()))
+}
+
+func TestNumaAllocation_MemSetHint(t *testing.T) {
+	alloc := &NumaAllocation{NodeIDs: []int{1, 0}}
+	require.Equal(t, "0,1", alloc.MemSetHint())
+}
+
+func TestFormatCPUList(t *testing.T) {
+	testCases := []struct {
+		input    []int
+		expected string
+	}{
+		{input: nil, expected: ""},
+		{input: []int{0}, expected: "0"},
+		{input: []int{0, 1, 2, 3}, expected: "0-3"},
+		{input: []int{0, 1, 4, 5, 6, 9}, expected: "0-1,4-6,9"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, formatCPUList(tc.input))
+	}
+}
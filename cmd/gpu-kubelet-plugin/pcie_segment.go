@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/utils/ptr"
+)
+
+// addPcieSegmentAttributes adds the per-device PCI segment attributes
+// computed by computePcieSegments onto an already-populated attribute map.
+func (g *GpuInfo) addPcieSegmentAttributes(attrs map[resourceapi.QualifiedName]resourceapi.DeviceAttribute) {
+	if g.pcieSegment == "" {
+		return
+	}
+
+	attrs[deviceAttributePrefix+"pcieSegment"] = resourceapi.DeviceAttribute{
+		StringValue: ptr.To(g.pcieSegment),
+	}
+	attrs[deviceAttributePrefix+"pcieSegmentPeers"] = resourceapi.DeviceAttribute{
+		IntValue: ptr.To(int64(g.pcieSegmentPeers)),
+	}
+}
+
+// computePcieSegments groups gpus by their pcieRootAttr value and assigns
+// each group a stable "segN" ID. Segments are ordered by the lowest PCI bus
+// ID among their member GPUs, so the same physical topology always yields
+// the same segment IDs regardless of NVML enumeration order (e.g. across
+// reboots). GPUs with no pcieRootAttr are left ungrouped.
+//
+// It returns a summary map of segment ID to member count, suitable for
+// publication as a ResourceSlice-level attribute.
+func computePcieSegments(gpus []*GpuInfo) map[string]int64 {
+	type group struct {
+		root    string
+		minBDF  string
+		members []*GpuInfo
+	}
+
+	groups := map[string]*group{}
+	for _, gpu := range gpus {
+		if gpu.pcieRootAttr == nil || gpu.pcieRootAttr.Value.StringValue == nil {
+			continue
+		}
+		root := *gpu.pcieRootAttr.Value.StringValue
+
+		g, ok := groups[root]
+		if !ok {
+			g = &group{root: root, minBDF: gpu.pcieBusID}
+			groups[root] = g
+		}
+		if gpu.pcieBusID < g.minBDF {
+			g.minBDF = gpu.pcieBusID
+		}
+		g.members = append(g.members, gpu)
+	}
+
+	ordered := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		ordered = append(ordered, g)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].minBDF < ordered[j].minBDF
+	})
+
+	summary := make(map[string]int64, len(ordered))
+	for i, g := range ordered {
+		segID := fmt.Sprintf("seg%d", i)
+		summary[segID] = int64(len(g.members))
+		for _, gpu := range g.members {
+			gpu.pcieSegment = segID
+			gpu.pcieSegmentPeers = len(g.members) - 1
+		}
+	}
+
+	return summary
+}
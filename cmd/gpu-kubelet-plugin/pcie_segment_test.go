@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/dynamic-resource-allocation/deviceattribute"
+	"k8s.io/utils/ptr"
+)
+
+func gpuWithPcieRoot(uuid, bdf, root string) *GpuInfo {
+	gpu := newTestGpuInfo(uuid, 0)
+	gpu.pcieBusID = bdf
+	gpu.pcieRootAttr = &deviceattribute.DeviceAttribute{
+		Name:  resourceapi.QualifiedName(deviceattribute.StandardDeviceAttributePrefix + "pcieRoot"),
+		Value: resourceapi.DeviceAttribute{StringValue: ptr.To(root)},
+	}
+	return gpu
+}
+
+func TestComputePcieSegments_GroupsByRoot(t *testing.T) {
+	gpu0 := gpuWithPcieRoot("GPU-0", "0000:17:00.0", "pci0000:16")
+	gpu1 := gpuWithPcieRoot("GPU-1", "0000:18:00.0", "pci0000:16")
+	gpu2 := gpuWithPcieRoot("GPU-2", "0000:65:00.0", "pci0000:64")
+
+	summary := computePcieSegments([]*GpuInfo{gpu0, gpu1, gpu2})
+
+	require.Equal(t, gpu0.pcieSegment, gpu1.pcieSegment, "GPU-0 and GPU-1 share a PCIe root and should share a segment")
+	require.NotEqual(t, gpu0.pcieSegment, gpu2.pcieSegment)
+	require.Equal(t, 1, gpu0.pcieSegmentPeers)
+	require.Equal(t, 0, gpu2.pcieSegmentPeers)
+	require.Equal(t, map[string]int64{gpu0.pcieSegment: 2, gpu2.pcieSegment: 1}, summary)
+}
+
+func TestComputePcieSegments_StableByLowestBDF(t *testing.T) {
+	// Segment assigned to the group containing the lowest BDF must always
+	// be "seg0", regardless of the order GPUs are enumerated in.
+	gpuLow := gpuWithPcieRoot("GPU-LOW", "0000:05:00.0", "pci0000:04")
+	gpuHigh := gpuWithPcieRoot("GPU-HIGH", "0000:65:00.0", "pci0000:64")
+
+	computePcieSegments([]*GpuInfo{gpuHigh, gpuLow})
+	require.Equal(t, "seg0", gpuLow.pcieSegment)
+	require.Equal(t, "seg1", gpuHigh.pcieSegment)
+
+	gpuLow2 := gpuWithPcieRoot("GPU-LOW", "0000:05:00.0", "pci0000:04")
+	gpuHigh2 := gpuWithPcieRoot("GPU-HIGH", "0000:65:00.0", "pci0000:64")
+	computePcieSegments([]*GpuInfo{gpuLow2, gpuHigh2})
+	require.Equal(t, "seg0", gpuLow2.pcieSegment)
+	require.Equal(t, "seg1", gpuHigh2.pcieSegment)
+}
+
+func TestComputePcieSegments_NoPcieRootIgnored(t *testing.T) {
+	gpu := newTestGpuInfo("GPU-0", 0)
+	summary := computePcieSegments([]*GpuInfo{gpu})
+	require.Empty(t, summary)
+	require.Empty(t, gpu.pcieSegment)
+}
+
+func TestGpuInfo_GetDevice_PcieSegmentAttributes(t *testing.T) {
+	gpu0 := gpuWithPcieRoot("GPU-0", "0000:17:00.0", "pci0000:16")
+	gpu1 := gpuWithPcieRoot("GPU-1", "0000:18:00.0", "pci0000:16")
+	computePcieSegments([]*GpuInfo{gpu0, gpu1})
+
+	device := gpu0.GetDevice()
+
+	segAttr, ok := device.Attributes[deviceAttributePrefix+"pcieSegment"]
+	require.True(t, ok)
+	require.Equal(t, "seg0", *segAttr.StringValue)
+
+	peersAttr, ok := device.Attributes[deviceAttributePrefix+"pcieSegmentPeers"]
+	require.True(t, ok)
+	require.Equal(t, int64(1), *peersAttr.IntValue)
+}
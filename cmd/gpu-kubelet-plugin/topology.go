@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/utils/ptr"
+)
+
+// P2PLinkType mirrors the NVML nvmlGpuP2PStatus_t / NVLink connection
+// strength between a pair of GPUs, ordered from weakest to strongest so that
+// callers can take the max when multiple link types are reported.
+type P2PLinkType int
+
+const (
+	P2PLinkUnknown P2PLinkType = iota
+	P2PLinkCrossCPU
+	P2PLinkHostBridge
+	P2PLinkSameCPU
+	P2PLinkMultiSwitch
+	P2PLinkSingleSwitch
+	P2PLinkSameBoard
+	P2PLinkNVLink
+)
+
+// String returns the NVML-style name for the link type.
+func (l P2PLinkType) String() string {
+	switch l {
+	case P2PLinkSameBoard:
+		return "P2PLinkSameBoard"
+	case P2PLinkSingleSwitch:
+		return "P2PLinkSingleSwitch"
+	case P2PLinkMultiSwitch:
+		return "P2PLinkMultiSwitch"
+	case P2PLinkHostBridge:
+		return "P2PLinkHostBridge"
+	case P2PLinkSameCPU:
+		return "P2PLinkSameCPU"
+	case P2PLinkCrossCPU:
+		return "P2PLinkCrossCPU"
+	case P2PLinkNVLink:
+		return "NVLink"
+	default:
+		return "Unknown"
+	}
+}
+
+// topologyGroupThreshold is the minimum P2PLinkType considered "tightly
+// connected" when partitioning GPUs into topology groups. GPUs linked only
+// below this threshold (e.g. across a host bridge) are not grouped together.
+const topologyGroupThreshold = P2PLinkSingleSwitch
+
+// addTopologyAttributes adds the per-device P2P topology attributes computed
+// by computeGpuTopology onto an already-populated attribute map.
+//
+// Per-peer link attributes are keyed by the peer's minor number
+// ("p2pLinkGpu<minor>") rather than its UUID: a QualifiedName's identifier
+// must be a C identifier of at most resourceapi.DeviceMaxIDLength
+// characters, and a real NVML UUID (GPU-xxxxxxxx-xxxx-...) satisfies
+// neither constraint.
+func (g *GpuInfo) addTopologyAttributes(attrs map[resourceapi.QualifiedName]resourceapi.DeviceAttribute) {
+	for peerMinor, link := range g.p2pLinks {
+		attrs[resourceapi.QualifiedName(fmt.Sprintf("%sp2pLinkGpu%d", deviceAttributePrefix, peerMinor))] = resourceapi.DeviceAttribute{
+			StringValue: ptr.To(link.String()),
+		}
+	}
+
+	if g.nvlinkPeerCount > 0 {
+		attrs[deviceAttributePrefix+"nvlinkPeers"] = resourceapi.DeviceAttribute{
+			IntValue: ptr.To(int64(g.nvlinkPeerCount)),
+		}
+	}
+
+	if g.topologyGroup != "" {
+		attrs[deviceAttributePrefix+"topologyGroup"] = resourceapi.DeviceAttribute{
+			StringValue: ptr.To(g.topologyGroup),
+		}
+	}
+}
+
+// nvmlP2PQuerier abstracts the NVML calls needed to determine the strongest
+// connection between two GPUs, so topology computation can be unit tested
+// without a real NVML library.
+type nvmlP2PQuerier interface {
+	// P2PLink returns the strongest P2PLinkType between a and b. It returns
+	// P2PLinkNVLink along with the active NVLink count when the pair is
+	// connected via NVLink.
+	P2PLink(a, b *GpuInfo) (link P2PLinkType, nvlinkCount int)
+}
+
+// computeGpuTopology populates p2pLinks, nvlinkPeerCount, and topologyGroup
+// on every GpuInfo in gpus by querying the pairwise connection type for each
+// GPU pair, then grouping GPUs into connected components under
+// topologyGroupThreshold. It is called once from the ResourceSlice
+// publication path after the GpuInfo set for the node has been enumerated.
+func computeGpuTopology(gpus []*GpuInfo, querier nvmlP2PQuerier) {
+	n := len(gpus)
+	if n == 0 {
+		return
+	}
+
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	union := func(a, b int) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[ra] = rb
+		}
+	}
+
+	for i := range gpus {
+		gpus[i].p2pLinks = map[int]P2PLinkType{}
+		gpus[i].nvlinkPeerCount = 0
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			link, nvlinkCount := querier.P2PLink(gpus[i], gpus[j])
+
+			gpus[i].p2pLinks[gpus[j].minor] = link
+			gpus[j].p2pLinks[gpus[i].minor] = link
+
+			if nvlinkCount > 0 {
+				gpus[i].nvlinkPeerCount++
+				gpus[j].nvlinkPeerCount++
+			}
+
+			if link >= topologyGroupThreshold {
+				union(i, j)
+			}
+		}
+	}
+
+	groupMembers := map[int][]string{}
+	for i, gpu := range gpus {
+		root := find(i)
+		groupMembers[root] = append(groupMembers[root], gpu.UUID)
+	}
+
+	for i, gpu := range gpus {
+		members := groupMembers[find(i)]
+		gpu.topologyGroup = hashTopologyGroup(members)
+	}
+}
+
+// hashTopologyGroup derives a short, stable identifier for a connected
+// component of GPU UUIDs. Sorting before hashing ensures the same group of
+// GPUs always yields the same ID regardless of enumeration order (e.g.
+// across reboots).
+func hashTopologyGroup(members []string) string {
+	sorted := append([]string(nil), members...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, uuid := range sorted {
+		h.Write([]byte(uuid))
+		h.Write([]byte{0})
+	}
+
+	return "grp-" + hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeP2PQuerier reports a fixed link type for each unordered GPU UUID pair,
+// defaulting to P2PLinkCrossCPU for any pair not explicitly configured.
+type fakeP2PQuerier struct {
+	links map[[2]string]P2PLinkType
+}
+
+func (f *fakeP2PQuerier) P2PLink(a, b *GpuInfo) (P2PLinkType, int) {
+	key := [2]string{a.UUID, b.UUID}
+	if link, ok := f.links[key]; ok {
+		if link == P2PLinkNVLink {
+			return link, 1
+		}
+		return link, 0
+	}
+	key = [2]string{b.UUID, a.UUID}
+	if link, ok := f.links[key]; ok {
+		if link == P2PLinkNVLink {
+			return link, 1
+		}
+		return link, 0
+	}
+	return P2PLinkCrossCPU, 0
+}
+
+func newTestGpuInfo(uuid string, minor int) *GpuInfo {
+	return &GpuInfo{
+		UUID:        uuid,
+		minor:       minor,
+		productName: "NVIDIA A100",
+		numaNode:    -1,
+		health:      Healthy,
+	}
+}
+
+func TestGpuInfo_GetDevice_TopologyAttributes(t *testing.T) {
+	gpu0 := newTestGpuInfo("GPU-0", 0)
+	gpu1 := newTestGpuInfo("GPU-1", 1)
+	gpu2 := newTestGpuInfo("GPU-2", 2)
+
+	querier := &fakeP2PQuerier{
+		links: map[[2]string]P2PLinkType{
+			{"GPU-0", "GPU-1"}: P2PLinkNVLink,
+			{"GPU-0", "GPU-2"}: P2PLinkHostBridge,
+			{"GPU-1", "GPU-2"}: P2PLinkHostBridge,
+		},
+	}
+
+	gpus := []*GpuInfo{gpu0, gpu1, gpu2}
+	computeGpuTopology(gpus, querier)
+
+	device0 := gpu0.GetDevice()
+
+	p2pAttr, ok := device0.Attributes[deviceAttributePrefix+"p2pLinkGpu1"]
+	require.True(t, ok, "expected p2pLink attribute for peer at minor 1")
+	require.Equal(t, P2PLinkNVLink.String(), *p2pAttr.StringValue)
+
+	p2pAttr, ok = device0.Attributes[deviceAttributePrefix+"p2pLinkGpu2"]
+	require.True(t, ok, "expected p2pLink attribute for peer at minor 2")
+	require.Equal(t, P2PLinkHostBridge.String(), *p2pAttr.StringValue)
+
+	nvlinkPeers, ok := device0.Attributes[deviceAttributePrefix+"nvlinkPeers"]
+	require.True(t, ok, "expected nvlinkPeers attribute")
+	require.Equal(t, int64(1), *nvlinkPeers.IntValue)
+}
+
+func TestGpuInfo_GetDevice_TopologyGroup(t *testing.T) {
+	// GPU-0 and GPU-1 are tightly connected (single switch); GPU-2 is only
+	// reachable via a host bridge, so it must land in its own group.
+	gpu0 := newTestGpuInfo("GPU-0", 0)
+	gpu1 := newTestGpuInfo("GPU-1", 1)
+	gpu2 := newTestGpuInfo("GPU-2", 2)
+
+	querier := &fakeP2PQuerier{
+		links: map[[2]string]P2PLinkType{
+			{"GPU-0", "GPU-1"}: P2PLinkSingleSwitch,
+			{"GPU-0", "GPU-2"}: P2PLinkHostBridge,
+			{"GPU-1", "GPU-2"}: P2PLinkHostBridge,
+		},
+	}
+
+	gpus := []*GpuInfo{gpu0, gpu1, gpu2}
+	computeGpuTopology(gpus, querier)
+
+	require.Equal(t, gpu0.topologyGroup, gpu1.topologyGroup, "GPU-0 and GPU-1 should share a topology group")
+	require.NotEqual(t, gpu0.topologyGroup, gpu2.topologyGroup, "GPU-2 should not share a topology group with GPU-0/GPU-1")
+
+	device2 := gpu2.GetDevice()
+	groupAttr, ok := device2.Attributes[deviceAttributePrefix+"topologyGroup"]
+	require.True(t, ok, "expected topologyGroup attribute")
+	require.Equal(t, gpu2.topologyGroup, *groupAttr.StringValue)
+}
+
+func TestHashTopologyGroup_StableAcrossOrder(t *testing.T) {
+	a := hashTopologyGroup([]string{"GPU-0", "GPU-1", "GPU-2"})
+	b := hashTopologyGroup([]string{"GPU-2", "GPU-0", "GPU-1"})
+	require.Equal(t, a, b, "topology group ID must not depend on enumeration order")
+}
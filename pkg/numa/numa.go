@@ -0,0 +1,267 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package numa discovers the host's NUMA topology from sysfs and scores
+// candidate GPU sets by their NUMA locality, so the claim-processing path
+// can prefer GPUs (and, eventually, CPUs/memory) that live close together.
+package numa
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysNodePath is the sysfs root for NUMA node discovery. It is a var so
+// tests can point it at a fixture directory.
+var sysNodePath = "/sys/devices/system/node"
+
+var nodeDirRegexp = regexp.MustCompile(`^node(\d+)$`)
+
+// Node describes a single NUMA node's CPU and memory resources.
+type Node struct {
+	// ID is the NUMA node index.
+	ID int
+	// CPUs is the set of logical CPU IDs local to this node.
+	CPUs []int
+	// MemoryBytes is the total memory attached to this node.
+	MemoryBytes uint64
+}
+
+// Topology is the host's NUMA node layout plus the inter-node distance
+// matrix reported by sysfs (ACPI SLIT).
+type Topology struct {
+	Nodes map[int]*Node
+	// Distances[i][j] is the relative hop-distance from node i to node j.
+	// A node's distance to itself is conventionally 10.
+	Distances map[int]map[int]int
+}
+
+// DiscoverTopology reads /sys/devices/system/node/ to build the host's
+// Topology. It returns an error if no NUMA nodes are present or sysfs is
+// unreadable, which callers should treat as "NUMA awareness unavailable"
+// rather than fatal.
+func DiscoverTopology() (*Topology, error) {
+	entries, err := os.ReadDir(sysNodePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", sysNodePath, err)
+	}
+
+	topo := &Topology{
+		Nodes:     map[int]*Node{},
+		Distances: map[int]map[int]int{},
+	}
+
+	for _, entry := range entries {
+		m := nodeDirRegexp.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		id, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+
+		nodeDir := filepath.Join(sysNodePath, entry.Name())
+
+		cpus, err := readCPUList(filepath.Join(nodeDir, "cpulist"))
+		if err != nil {
+			return nil, fmt.Errorf("reading cpulist for node %d: %w", id, err)
+		}
+
+		memBytes, err := readNodeMemTotal(filepath.Join(nodeDir, "meminfo"))
+		if err != nil {
+			return nil, fmt.Errorf("reading meminfo for node %d: %w", id, err)
+		}
+
+		distances, err := readDistance(filepath.Join(nodeDir, "distance"))
+		if err != nil {
+			return nil, fmt.Errorf("reading distance for node %d: %w", id, err)
+		}
+
+		topo.Nodes[id] = &Node{ID: id, CPUs: cpus, MemoryBytes: memBytes}
+		topo.Distances[id] = distances
+	}
+
+	if len(topo.Nodes) == 0 {
+		return nil, fmt.Errorf("no NUMA nodes found under %s", sysNodePath)
+	}
+
+	return topo, nil
+}
+
+// readCPUList parses a Linux list-format CPU range string, e.g. "0-3,8-11".
+func readCPUList(path string) ([]int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCPUList(string(raw))
+}
+
+// parseCPUList parses a comma-separated list of CPU IDs and/or ranges, e.g.
+// "0-3,8-11", as found both in sysfs cpulist files and in the
+// "gpu.nvidia.com/preferredCPUs" claim parameter.
+func parseCPUList(s string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(strings.TrimSpace(s), ",") {
+		if part == "" {
+			continue
+		}
+		if lo, hi, ok := strings.Cut(part, "-"); ok {
+			loN, err := strconv.Atoi(lo)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CPU range %q: %w", part, err)
+			}
+			hiN, err := strconv.Atoi(hi)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CPU range %q: %w", part, err)
+			}
+			for c := loN; c <= hiN; c++ {
+				cpus = append(cpus, c)
+			}
+		} else {
+			c, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CPU entry %q: %w", part, err)
+			}
+			cpus = append(cpus, c)
+		}
+	}
+
+	return cpus, nil
+}
+
+// readNodeMemTotal extracts the "MemTotal" value (in bytes) from a node's
+// meminfo file, which reports it in kB, e.g. "Node 0 MemTotal:  131072000 kB".
+func readNodeMemTotal(path string) (uint64, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(string(raw), "\n") {
+		if !strings.Contains(line, "MemTotal:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, f := range fields {
+			if f == "MemTotal:" && i+1 < len(fields) {
+				kb, err := strconv.ParseUint(fields[i+1], 10, 64)
+				if err != nil {
+					return 0, fmt.Errorf("parsing MemTotal from %q: %w", line, err)
+				}
+				return kb * 1024, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("MemTotal not found in %s", path)
+}
+
+// readDistance parses a node's space-separated distance row, keyed by node
+// ID in ascending order (the same order sysfs reports them in).
+func readDistance(path string) (map[int]int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(raw))
+	distances := make(map[int]int, len(fields))
+	for i, f := range fields {
+		d, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid distance entry %q: %w", f, err)
+		}
+		distances[i] = d
+	}
+
+	return distances, nil
+}
+
+// CandidateScore is the result of scoring one candidate set of NUMA nodes
+// for a multi-GPU claim. Lower Distance is better; ties are broken by
+// CPUOverlap (higher is better).
+type CandidateScore struct {
+	Nodes []int
+	// SameNode is true when every requested GPU lives on a single NUMA node.
+	SameNode bool
+	// Distance is the summed pairwise hop-distance across the requested
+	// GPUs' NUMA nodes. Zero when SameNode is true.
+	Distance int
+	// CPUOverlap is the number of preferred CPUs that fall within the
+	// candidate's combined CPU set.
+	CPUOverlap int
+}
+
+// ScoreNodes scores a candidate set of NUMA node IDs (one per requested GPU,
+// duplicates allowed) against the topology and an optional preferred CPU
+// set. Callers sort candidates by (SameNode desc, Distance asc, CPUOverlap
+// desc) to pick the best.
+func (t *Topology) ScoreNodes(nodeIDs []int, preferredCPUs []int) CandidateScore {
+	unique := map[int]bool{}
+	for _, id := range nodeIDs {
+		unique[id] = true
+	}
+
+	score := CandidateScore{Nodes: nodeIDs}
+	if len(unique) <= 1 {
+		score.SameNode = true
+	} else {
+		ids := make([]int, 0, len(unique))
+		for id := range unique {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for i := 0; i < len(ids); i++ {
+			for j := i + 1; j < len(ids); j++ {
+				score.Distance += t.Distances[ids[i]][ids[j]]
+			}
+		}
+	}
+
+	if len(preferredCPUs) > 0 {
+		cpuSet := map[int]bool{}
+		for id := range unique {
+			node, ok := t.Nodes[id]
+			if !ok {
+				continue
+			}
+			for _, cpu := range node.CPUs {
+				cpuSet[cpu] = true
+			}
+		}
+		for _, cpu := range preferredCPUs {
+			if cpuSet[cpu] {
+				score.CPUOverlap++
+			}
+		}
+	}
+
+	return score
+}
+
+// ParseCPUList parses a "gpu.nvidia.com/preferredCPUs"-style claim parameter
+// value, e.g. "0-3,8", into individual CPU IDs.
+func ParseCPUList(s string) ([]int, error) {
+	return parseCPUList(s)
+}
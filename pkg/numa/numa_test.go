@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package numa
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopology_ScoreNodes(t *testing.T) {
+	topo := &Topology{
+		Nodes: map[int]*Node{
+			0: {ID: 0, CPUs: []int{0, 1, 2, 3}},
+			1: {ID: 1, CPUs: []int{4, 5, 6, 7}},
+		},
+		Distances: map[int]map[int]int{
+			0: {0: 10, 1: 21},
+			1: {0: 21, 1: 10},
+		},
+	}
+
+	testCases := []struct {
+		description      string
+		nodeIDs          []int
+		preferredCPUs    []int
+		expectSameNode   bool
+		expectedDistance int
+		expectedOverlap  int
+	}{
+		{
+			description:    "both GPUs on node 0",
+			nodeIDs:        []int{0, 0},
+			expectSameNode: true,
+		},
+		{
+			description:      "GPUs split across node 0 and node 1",
+			nodeIDs:          []int{0, 1},
+			expectSameNode:   false,
+			expectedDistance: 21,
+		},
+		{
+			description:     "preferred CPUs overlap node 0",
+			nodeIDs:         []int{0, 0},
+			preferredCPUs:   []int{1, 2, 99},
+			expectSameNode:  true,
+			expectedOverlap: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			score := topo.ScoreNodes(tc.nodeIDs, tc.preferredCPUs)
+			require.Equal(t, tc.expectSameNode, score.SameNode)
+			require.Equal(t, tc.expectedDistance, score.Distance)
+			require.Equal(t, tc.expectedOverlap, score.CPUOverlap)
+		})
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []int
+	}{
+		{input: "", expected: nil},
+		{input: "0", expected: []int{0}},
+		{input: "0-3", expected: []int{0, 1, 2, 3}},
+		{input: "0-1,8,10-11", expected: []int{0, 1, 8, 10, 11}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			cpus, err := ParseCPUList(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, cpus)
+		})
+	}
+
+	_, err := ParseCPUList("not-a-number")
+	require.Error(t, err)
+}
+
+func TestDiscoverTopology(t *testing.T) {
+	root := t.TempDir()
+	nodeDir := filepath.Join(root, "node0")
+	require.NoError(t, os.MkdirAll(nodeDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(nodeDir, "cpulist"), []byte("0-3\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(nodeDir, "meminfo"), []byte("Node 0 MemTotal:       131072 kB\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(nodeDir, "distance"), []byte("10\n"), 0o644))
+
+	orig := sysNodePath
+	sysNodePath = root
+	defer func() { sysNodePath = orig }()
+
+	topo, err := DiscoverTopology()
+	require.NoError(t, err)
+	require.Contains(t, topo.Nodes, 0)
+	require.Equal(t, []int{0, 1, 2, 3}, topo.Nodes[0].CPUs)
+	require.Equal(t, uint64(131072*1024), topo.Nodes[0].MemoryBytes)
+	require.Equal(t, 10, topo.Distances[0][0])
+}
+
+func TestDiscoverTopology_NoNodes(t *testing.T) {
+	root := t.TempDir()
+
+	orig := sysNodePath
+	sysNodePath = root
+	defer func() { sysNodePath = orig }()
+
+	_, err := DiscoverTopology()
+	require.Error(t, err)
+}